@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cluster provides the client side of the internal ClusterService,
+// so that subsystems such as housekeeping and the admin API can talk to the
+// node that owns a document without each reimplementing connect-go
+// boilerplate.
+package cluster
+
+import (
+	"context"
+	"net/http"
+
+	"connectrpc.com/connect"
+
+	"github.com/yorkie-team/yorkie/api/converter"
+	"github.com/yorkie-team/yorkie/api/types"
+	api "github.com/yorkie-team/yorkie/api/yorkie/v1"
+	"github.com/yorkie-team/yorkie/api/yorkie/v1/v1connect"
+	"github.com/yorkie-team/yorkie/pkg/document/change"
+	"github.com/yorkie-team/yorkie/server/backend/database"
+)
+
+// Client is a wrapper around the generated ClusterService client that
+// speaks in terms of the server's own domain types instead of API messages.
+type Client struct {
+	conn   *http.Client
+	client v1connect.ClusterServiceClient
+}
+
+// NewClient creates a new instance of Client that talks to the ClusterService
+// hosted at addr. It gets its own *http.Client rather than sharing
+// http.DefaultClient, since Close calls CloseIdleConnections on it and that
+// would otherwise tear down idle connections for every other unrelated user
+// of the process-wide default client.
+func NewClient(addr string) *Client {
+	conn := &http.Client{}
+	return &Client{
+		conn:   conn,
+		client: v1connect.NewClusterServiceClient(conn, addr),
+	}
+}
+
+// DetachDocument requests the owning node to detach the given document from
+// the given client.
+func (c *Client) DetachDocument(
+	ctx context.Context,
+	project *types.Project,
+	docInfo *database.DocInfo,
+	clientID types.ID,
+) error {
+	_, err := c.client.DetachDocument(ctx, connect.NewRequest(&api.ClusterServiceDetachDocumentRequest{
+		DocumentSummary: converter.ToDocumentSummary(docInfo),
+		Project:         converter.ToProject(project),
+		ClientId:        clientID.String(),
+	}))
+	return err
+}
+
+// DetachDocuments requests the owning node(s) to detach every given document
+// from the given client in a single call, e.g. on client deactivation.
+func (c *Client) DetachDocuments(
+	ctx context.Context,
+	docs []DetachDocumentsItem,
+	clientID types.ID,
+) error {
+	elems := make([]*api.DetachDocumentsElement, 0, len(docs))
+	for _, d := range docs {
+		elems = append(elems, &api.DetachDocumentsElement{
+			DocumentSummary: converter.ToDocumentSummary(d.DocInfo),
+			Project:         converter.ToProject(d.Project),
+		})
+	}
+
+	_, err := c.client.DetachDocuments(ctx, connect.NewRequest(&api.ClusterServiceDetachDocumentsRequest{
+		Documents: elems,
+		ClientId:  clientID.String(),
+	}))
+	return err
+}
+
+// DetachDocumentsItem pairs a document with the project it belongs to for a
+// DetachDocuments call.
+type DetachDocumentsItem struct {
+	Project *types.Project
+	DocInfo *database.DocInfo
+}
+
+// AttachDocument requests the owning node to force-attach the given document
+// to the given client, carrying over the ChangePack the client originally
+// attached with so its initial content and presence aren't dropped at the
+// cluster boundary.
+func (c *Client) AttachDocument(
+	ctx context.Context,
+	project *types.Project,
+	docInfo *database.DocInfo,
+	clientID types.ID,
+	pack *change.Pack,
+) error {
+	_, err := c.client.AttachDocument(ctx, connect.NewRequest(&api.ClusterServiceAttachDocumentRequest{
+		DocumentSummary: converter.ToDocumentSummary(docInfo),
+		Project:         converter.ToProject(project),
+		ClientId:        clientID.String(),
+		ChangePack:      converter.ToChangePack(pack),
+	}))
+	return err
+}
+
+// RemoveDocument requests the owning node to tombstone the given document.
+func (c *Client) RemoveDocument(
+	ctx context.Context,
+	project *types.Project,
+	docInfo *database.DocInfo,
+) error {
+	_, err := c.client.RemoveDocument(ctx, connect.NewRequest(&api.ClusterServiceRemoveDocumentRequest{
+		DocumentSummary: converter.ToDocumentSummary(docInfo),
+		Project:         converter.ToProject(project),
+	}))
+	return err
+}
+
+// CompactDocument requests the owning node to compact the history of the
+// given document.
+func (c *Client) CompactDocument(
+	ctx context.Context,
+	project *types.Project,
+	docInfo *database.DocInfo,
+) error {
+	_, err := c.client.CompactDocument(ctx, connect.NewRequest(&api.ClusterServiceCompactDocumentRequest{
+		DocumentSummary: converter.ToDocumentSummary(docInfo),
+		Project:         converter.ToProject(project),
+	}))
+	return err
+}
+
+// BroadcastEvent requests the node this Client talks to to publish the given
+// event to its local pubsub.
+func (c *Client) BroadcastEvent(
+	ctx context.Context,
+	project *types.Project,
+	docInfo *database.DocInfo,
+	publisherID types.ID,
+	eventType api.DocEventType,
+	payload []byte,
+) error {
+	_, err := c.client.BroadcastEvent(ctx, connect.NewRequest(&api.ClusterServiceBroadcastEventRequest{
+		DocumentSummary: converter.ToDocumentSummary(docInfo),
+		Project:         converter.ToProject(project),
+		PublisherId:     publisherID.String(),
+		EventType:       eventType,
+		Payload:         payload,
+	}))
+	return err
+}
+
+// Close closes the idle connections held by the underlying HTTP client.
+func (c *Client) Close() {
+	c.conn.CloseIdleConnections()
+}
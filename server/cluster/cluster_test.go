@@ -0,0 +1,198 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	gotime "time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yorkie-team/yorkie/api/types"
+	"github.com/yorkie-team/yorkie/server/cluster"
+)
+
+// fakeRegistry is an in-memory Registry used only for tests: it has no
+// persistence and no TTL sweeping of its own, since Members does the TTL
+// filtering on read.
+type fakeRegistry struct {
+	mu    sync.Mutex
+	nodes map[types.ID]cluster.NodeInfo
+	now   gotime.Time
+}
+
+func newFakeRegistry(now gotime.Time) *fakeRegistry {
+	return &fakeRegistry{nodes: map[types.ID]cluster.NodeInfo{}, now: now}
+}
+
+func (r *fakeRegistry) Register(_ context.Context, node cluster.NodeInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node.UpdatedAt = r.now
+	r.nodes[node.ID] = node
+	return nil
+}
+
+func (r *fakeRegistry) Heartbeat(_ context.Context, id types.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node, ok := r.nodes[id]
+	if !ok {
+		return assert.AnError
+	}
+	node.UpdatedAt = r.now
+	r.nodes[id] = node
+	return nil
+}
+
+func (r *fakeRegistry) Deregister(_ context.Context, id types.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.nodes, id)
+	return nil
+}
+
+func (r *fakeRegistry) Members(_ context.Context, ttl gotime.Duration) ([]cluster.NodeInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members := make([]cluster.NodeInfo, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		if !node.IsExpired(ttl, r.now) {
+			members = append(members, node)
+		}
+	}
+	return members, nil
+}
+
+// TestRouterRouteIsStableAcrossCalls asserts that Route resolves the same
+// document to the same owner on repeated calls as long as membership hasn't
+// changed, which requires sorting the member list into a canonical order
+// before hashing into it.
+func TestRouterRouteIsStableAcrossCalls(t *testing.T) {
+	now := gotime.Now()
+	registry := newFakeRegistry(now)
+	ctx := context.Background()
+
+	assert.NoError(t, registry.Register(ctx, cluster.NodeInfo{ID: "node-a", Addr: "a:1101"}))
+	assert.NoError(t, registry.Register(ctx, cluster.NodeInfo{ID: "node-b", Addr: "b:1101"}))
+	assert.NoError(t, registry.Register(ctx, cluster.NodeInfo{ID: "node-c", Addr: "c:1101"}))
+
+	router := cluster.NewRouter("node-a", registry)
+	docRefKey := types.DocRefKey{ProjectID: "project-1", DocID: "doc-1"}
+
+	first, err := router.Route(ctx, docRefKey)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		route, err := router.Route(ctx, docRefKey)
+		assert.NoError(t, err)
+		assert.Equal(t, first.Local, route.Local)
+		assert.Equal(t, first.NodeID, route.NodeID)
+	}
+}
+
+// TestRouterRouteFallsBackToSelfWhenUnregistered asserts that Route can
+// still resolve Local for some documents even when this node's own
+// membership entry hasn't reached the registry yet (e.g. before
+// Membership.Start's first heartbeat lands, or if Membership was never
+// started). Without this, a node that doesn't yet see itself in the
+// registry would forward every single request to a peer.
+func TestRouterRouteFallsBackToSelfWhenUnregistered(t *testing.T) {
+	now := gotime.Now()
+	registry := newFakeRegistry(now)
+	ctx := context.Background()
+
+	assert.NoError(t, registry.Register(ctx, cluster.NodeInfo{ID: "node-b", Addr: "b:1101"}))
+
+	router := cluster.NewRouter("node-a", registry)
+
+	var sawLocal bool
+	for i := 0; i < 32 && !sawLocal; i++ {
+		docRefKey := types.DocRefKey{ProjectID: "project-1", DocID: types.ID(fmt.Sprintf("doc-%02d", i))}
+		route, err := router.Route(ctx, docRefKey)
+		assert.NoError(t, err)
+		if route.Local {
+			sawLocal = true
+		}
+	}
+
+	assert.True(t, sawLocal, "expected at least one document to route locally even though node-a isn't registered yet")
+}
+
+// TestMembershipLifecycle asserts that Start publishes this node's entry to
+// the Registry and Stop removes it again, so Router.Route sees an accurate
+// membership snapshot across a node's lifetime.
+func TestMembershipLifecycle(t *testing.T) {
+	now := gotime.Now()
+	registry := newFakeRegistry(now)
+	ctx := context.Background()
+
+	membership := cluster.NewMembership(
+		cluster.NodeInfo{ID: "node-a", Addr: "a:1101"},
+		registry,
+		nil,
+	)
+
+	assert.NoError(t, membership.Start(ctx))
+
+	members, err := registry.Members(ctx, cluster.DefaultNodeTTL)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, types.ID("node-a"), members[0].ID)
+
+	assert.NoError(t, membership.Stop(ctx))
+
+	members, err = registry.Members(ctx, cluster.DefaultNodeTTL)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+// TestLocalRegistryLifecycle asserts that LocalRegistry, the concrete
+// Registry this package ships (rather than only a test fake), honors the
+// same register/heartbeat/expire/deregister contract Router and Membership
+// depend on.
+func TestLocalRegistryLifecycle(t *testing.T) {
+	ctx := context.Background()
+	registry := cluster.NewLocalRegistry()
+
+	assert.NoError(t, registry.Register(ctx, cluster.NodeInfo{ID: "node-a", Addr: "a:1101"}))
+
+	members, err := registry.Members(ctx, cluster.DefaultNodeTTL)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, types.ID("node-a"), members[0].ID)
+
+	// A node with no prior Register call isn't heartbeatable.
+	assert.Error(t, registry.Heartbeat(ctx, "node-b"))
+
+	assert.NoError(t, registry.Heartbeat(ctx, "node-a"))
+
+	// A negative TTL means even a just-refreshed heartbeat already counts as
+	// expired, so Members should filter it out.
+	members, err = registry.Members(ctx, -gotime.Second)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+
+	assert.NoError(t, registry.Deregister(ctx, "node-a"))
+
+	members, err = registry.Members(ctx, cluster.DefaultNodeTTL)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	gotime "time"
+
+	"github.com/yorkie-team/yorkie/api/types"
+)
+
+// DefaultHeartbeatInterval is how often a node refreshes its membership
+// entry in the Registry.
+const DefaultHeartbeatInterval = 5 * gotime.Second
+
+// DefaultNodeTTL is how long a node's membership entry is considered valid
+// after its last heartbeat before it is treated as dead.
+const DefaultNodeTTL = 3 * DefaultHeartbeatInterval
+
+// NodeInfo is the membership record a Yorkie node publishes to the
+// Registry so that other nodes can discover it and route requests to it.
+type NodeInfo struct {
+	ID   types.ID
+	Addr string
+
+	UpdatedAt gotime.Time
+}
+
+// IsExpired reports whether this node's membership entry is stale given ttl.
+func (n NodeInfo) IsExpired(ttl gotime.Duration, now gotime.Time) bool {
+	return now.Sub(n.UpdatedAt) > ttl
+}
+
+// Registry stores and discovers the set of live nodes in the cluster. The
+// only implementation in this package, LocalRegistry, keeps entries in
+// process memory: membership is shared only between nodes in the same
+// process and is not kept across restarts. No implementation backed by the
+// coordinator's shared storage (etcd or the configured DB) exists here.
+type Registry interface {
+	// Register publishes this node's membership entry.
+	Register(ctx context.Context, node NodeInfo) error
+
+	// Heartbeat refreshes the UpdatedAt of this node's membership entry.
+	// It returns an error if the node is not currently registered.
+	Heartbeat(ctx context.Context, id types.ID) error
+
+	// Deregister removes this node's membership entry, e.g. on graceful
+	// shutdown.
+	Deregister(ctx context.Context, id types.ID) error
+
+	// Members returns the membership entries of every node that has
+	// heartbeat within ttl.
+	Members(ctx context.Context, ttl gotime.Duration) ([]NodeInfo, error)
+}
+
+// RebalanceHook is invoked by Membership when it detects that a node has
+// left the cluster (missed its TTL), so callers can react, e.g. by
+// forgetting cached routes to it. Since Router.Route owns a document to a
+// node by hashing over the live member list rather than a tracked shard
+// range, a departure like this reshuffles ownership of most documents, not
+// just the departed node's share.
+type RebalanceHook func(left NodeInfo, members []NodeInfo)
+
+// Membership keeps this node's entry in the Registry alive with periodic
+// heartbeats and watches for peers that have gone silent.
+type Membership struct {
+	self     NodeInfo
+	registry Registry
+	interval gotime.Duration
+	ttl      gotime.Duration
+	onLeave  RebalanceHook
+
+	closeCh chan struct{}
+}
+
+// NewMembership creates a new Membership for self, backed by registry.
+func NewMembership(self NodeInfo, registry Registry, onLeave RebalanceHook) *Membership {
+	return &Membership{
+		self:     self,
+		registry: registry,
+		interval: DefaultHeartbeatInterval,
+		ttl:      DefaultNodeTTL,
+		onLeave:  onLeave,
+		closeCh:  make(chan struct{}),
+	}
+}
+
+// Start registers this node and begins sending periodic heartbeats until
+// Stop is called.
+func (m *Membership) Start(ctx context.Context) error {
+	if err := m.registry.Register(ctx, m.self); err != nil {
+		return fmt.Errorf("register node %s: %w", m.self.ID, err)
+	}
+
+	go m.keepalive(ctx)
+
+	return nil
+}
+
+// Stop deregisters this node and stops sending heartbeats.
+func (m *Membership) Stop(ctx context.Context) error {
+	close(m.closeCh)
+	return m.registry.Deregister(ctx, m.self.ID)
+}
+
+func (m *Membership) keepalive(ctx context.Context) {
+	ticker := gotime.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	prevMembers := map[types.ID]NodeInfo{}
+
+	for {
+		select {
+		case <-m.closeCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.registry.Heartbeat(ctx, m.self.ID); err != nil {
+				continue
+			}
+
+			members, err := m.registry.Members(ctx, m.ttl)
+			if err != nil {
+				continue
+			}
+			m.detectDepartures(members, prevMembers)
+
+			prevMembers = make(map[types.ID]NodeInfo, len(members))
+			for _, member := range members {
+				prevMembers[member.ID] = member
+			}
+		}
+	}
+}
+
+// detectDepartures calls onLeave for every node that was present in
+// prevMembers but is missing from the latest members snapshot.
+func (m *Membership) detectDepartures(members []NodeInfo, prevMembers map[types.ID]NodeInfo) {
+	if m.onLeave == nil {
+		return
+	}
+
+	seen := make(map[types.ID]bool, len(members))
+	for _, member := range members {
+		seen[member.ID] = true
+	}
+
+	for id, prev := range prevMembers {
+		if !seen[id] {
+			m.onLeave(prev, members)
+		}
+	}
+}
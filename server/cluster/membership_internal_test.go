@@ -0,0 +1,53 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yorkie-team/yorkie/api/types"
+)
+
+// TestMembershipDetectDepartures asserts that detectDepartures fires
+// onLeave exactly once for a node present in prevMembers but missing from
+// the latest Members snapshot, and not at all when membership is unchanged.
+func TestMembershipDetectDepartures(t *testing.T) {
+	var left []NodeInfo
+	m := NewMembership(
+		NodeInfo{ID: "node-a"},
+		nil,
+		func(node NodeInfo, _ []NodeInfo) {
+			left = append(left, node)
+		},
+	)
+
+	prev := map[types.ID]NodeInfo{
+		"node-a": {ID: "node-a"},
+		"node-b": {ID: "node-b"},
+	}
+
+	m.detectDepartures([]NodeInfo{{ID: "node-a"}}, prev)
+
+	assert.Len(t, left, 1)
+	assert.Equal(t, types.ID("node-b"), left[0].ID)
+
+	left = nil
+	m.detectDepartures([]NodeInfo{{ID: "node-a"}, {ID: "node-b"}}, prev)
+	assert.Empty(t, left)
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	gotime "time"
+
+	"github.com/yorkie-team/yorkie/api/types"
+)
+
+// LocalRegistry is a Registry backed by process memory. It is a real,
+// concrete implementation rather than a test fake, but it only shares
+// membership between nodes running in the same process: a production,
+// multi-process deployment needs a Registry backed by the coordinator's
+// shared storage (etcd or the configured DB) instead, so that every node
+// sees the same membership. That one needs a key/value storage primitive
+// on top of the coordinator this tree doesn't define yet -- the
+// coordinator's only known operation here is NewLocker -- so it isn't
+// implemented in this package.
+type LocalRegistry struct {
+	mu    sync.Mutex
+	nodes map[types.ID]NodeInfo
+}
+
+// NewLocalRegistry creates an empty LocalRegistry.
+func NewLocalRegistry() *LocalRegistry {
+	return &LocalRegistry{nodes: make(map[types.ID]NodeInfo)}
+}
+
+// Register publishes node's membership entry.
+func (r *LocalRegistry) Register(_ context.Context, node NodeInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node.UpdatedAt = gotime.Now()
+	r.nodes[node.ID] = node
+	return nil
+}
+
+// Heartbeat refreshes the UpdatedAt of id's membership entry.
+func (r *LocalRegistry) Heartbeat(_ context.Context, id types.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node, ok := r.nodes[id]
+	if !ok {
+		return fmt.Errorf("node %s is not registered", id)
+	}
+	node.UpdatedAt = gotime.Now()
+	r.nodes[id] = node
+	return nil
+}
+
+// Deregister removes id's membership entry.
+func (r *LocalRegistry) Deregister(_ context.Context, id types.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.nodes, id)
+	return nil
+}
+
+// Members returns every node that has heartbeat within ttl.
+func (r *LocalRegistry) Members(_ context.Context, ttl gotime.Duration) ([]NodeInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := gotime.Now()
+	members := make([]NodeInfo, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		if !node.IsExpired(ttl, now) {
+			members = append(members, node)
+		}
+	}
+	return members, nil
+}
+
+// Node bundles the Router and Membership for a single cluster node, wired
+// together: when Membership detects a peer has left, the Router forgets its
+// cached Client for that peer, so a stale connection doesn't linger after a
+// node it was talking to is gone. This is the real RebalanceHook production
+// code is expected to install; everywhere else it's installed is a test.
+type Node struct {
+	Router     *Router
+	Membership *Membership
+}
+
+// NewNode creates a Node for self, backed by registry. Start/Stop drive its
+// Membership's lifecycle; Router is what ClusterService RPC handlers (see
+// rpc.RegisterClusterServiceHandler) should route document operations
+// through.
+func NewNode(self NodeInfo, registry Registry) *Node {
+	router := NewRouter(self.ID, registry)
+	membership := NewMembership(self, registry, func(left NodeInfo, _ []NodeInfo) {
+		router.Forget(left.ID)
+	})
+
+	return &Node{
+		Router:     router,
+		Membership: membership,
+	}
+}
+
+// Start registers this node and begins heartbeating. See Membership.Start.
+func (n *Node) Start(ctx context.Context) error {
+	return n.Membership.Start(ctx)
+}
+
+// Stop deregisters this node and stops heartbeating. See Membership.Stop.
+func (n *Node) Stop(ctx context.Context) error {
+	return n.Membership.Stop(ctx)
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yorkie-team/yorkie/api/types"
+)
+
+// TestNodeForgetsRouterClientOnPeerDeparture asserts that NewNode wires
+// Membership's RebalanceHook to Router.Forget, so a peer's cached Client is
+// dropped as soon as Membership notices it has left the cluster, instead of
+// lingering forever with no real hook ever installed.
+func TestNodeForgetsRouterClientOnPeerDeparture(t *testing.T) {
+	node := NewNode(NodeInfo{ID: "node-a"}, nil)
+
+	peer := NodeInfo{ID: "node-b", Addr: "b:1101"}
+	node.Router.clientFor(peer)
+	assert.Contains(t, node.Router.clients, peer.ID)
+
+	node.Membership.detectDepartures(nil, map[types.ID]NodeInfo{peer.ID: peer})
+
+	assert.NotContains(t, node.Router.clients, peer.ID)
+}
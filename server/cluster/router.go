@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	gotime "time"
+
+	"github.com/yorkie-team/yorkie/api/types"
+)
+
+// Route describes where a request for a document should go: either handled
+// locally, or forwarded to the Client for the node that owns it.
+type Route struct {
+	Local  bool
+	NodeID types.ID
+	Client *Client
+}
+
+// Router resolves which node owns a given document and returns a Route
+// that callers can use to either handle the request locally or forward it
+// to the owning node via the ClusterService.
+//
+// There is no tracked shard range: ownership is a hash of the document key
+// modulo the current, sorted member list. That means it is membership-count
+// routing, not consistent hashing — a single node joining or leaving remaps
+// the owner of roughly (N-1)/N of all documents, not just the affected
+// node's share. A real consistent-hash ring (or rendezvous hashing) keyed
+// by a tracked shard would bound that churn to a node's own shards; until
+// that lands, callers should expect a membership change to be expensive.
+type Router struct {
+	self     types.ID
+	registry Registry
+	ttl      gotime.Duration
+
+	mu      sync.RWMutex
+	clients map[types.ID]*Client
+}
+
+// NewRouter creates a new Router for a node identified by self.
+func NewRouter(self types.ID, registry Registry) *Router {
+	return &Router{
+		self:     self,
+		registry: registry,
+		ttl:      DefaultNodeTTL,
+		clients:  make(map[types.ID]*Client),
+	}
+}
+
+// Route resolves the node that owns docRefKey and returns a Route to it.
+// See the Router doc comment: this hashes the document key over the live,
+// sorted member set, it does not consult a tracked shard range.
+func (r *Router) Route(ctx context.Context, docRefKey types.DocRefKey) (Route, error) {
+	members, err := r.registry.Members(ctx, r.ttl)
+	if err != nil {
+		return Route{}, fmt.Errorf("list members: %w", err)
+	}
+
+	// This node is always a candidate, whether or not the registry already
+	// has its heartbeat: without this, a node whose own Membership hasn't
+	// registered yet (or was never started at all) would never appear in
+	// members, so Route could never resolve Local for any document and
+	// would forward every request to some peer instead.
+	if !containsNode(members, r.self) {
+		members = append(members, NodeInfo{ID: r.self})
+	}
+
+	// registry.Members makes no ordering guarantee, so sort into a
+	// canonical order first. Otherwise the same document could hash to a
+	// different "owner" on two calls even though membership hasn't
+	// changed, which would defeat the whole point of a stable owner.
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+
+	owner := members[memberIndex(docRefKey, len(members))]
+	if owner.ID == r.self {
+		return Route{Local: true, NodeID: r.self}, nil
+	}
+
+	return Route{NodeID: owner.ID, Client: r.clientFor(owner)}, nil
+}
+
+// containsNode reports whether members contains a node with the given ID.
+func containsNode(members []NodeInfo, id types.ID) bool {
+	for _, m := range members {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// memberIndex hashes a document's ref key to a stable index into a member
+// list of the given size.
+func memberIndex(docRefKey types.DocRefKey, memberCount int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(docRefKey.ProjectID.String()))
+	_, _ = h.Write([]byte(docRefKey.DocID.String()))
+	return int(h.Sum32()) % memberCount
+}
+
+// clientFor returns a cached ClusterService client for node, creating one
+// if this is the first time we've needed to talk to it.
+func (r *Router) clientFor(node NodeInfo) *Client {
+	r.mu.RLock()
+	client, ok := r.clients[node.ID]
+	r.mu.RUnlock()
+	if ok {
+		return client
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if client, ok := r.clients[node.ID]; ok {
+		return client
+	}
+
+	client = NewClient(node.Addr)
+	r.clients[node.ID] = client
+	return client
+}
+
+// Forget drops the cached client for a node, e.g. after it has left the
+// cluster, so a fresh connection is established if it rejoins.
+func (r *Router) Forget(id types.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, id)
+}
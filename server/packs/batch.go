@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package packs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yorkie-team/yorkie/api/types"
+	"github.com/yorkie-team/yorkie/pkg/document"
+	"github.com/yorkie-team/yorkie/pkg/document/time"
+	"github.com/yorkie-team/yorkie/server/backend"
+	"github.com/yorkie-team/yorkie/server/backend/database"
+	"github.com/yorkie-team/yorkie/server/logging"
+)
+
+// DefaultBatchParallelism is the number of documents that PushPullBatch
+// processes concurrently when the caller doesn't specify its own limit.
+const DefaultBatchParallelism = 16
+
+// BatchItem is a single document targeted by a PushPullBatch call.
+type BatchItem struct {
+	Project *types.Project
+	DocInfo *database.DocInfo
+}
+
+// lockKey returns the PushPullKey for this item.
+func (b BatchItem) lockKey() string {
+	return PushPullKey(b.Project.ID, b.DocInfo.Key)
+}
+
+// PushPullBatch clears the given actor's presence from every item, running
+// up to parallelism PushPulls concurrently. Each item takes its own
+// per-document lock via PushPullDetach, acquired and released within that
+// single call, so there is no hold-and-wait cycle across items for a fixed
+// acquisition order to guard against. It returns one error per item,
+// aligned by index, with a nil entry for items that succeeded.
+func PushPullBatch(
+	ctx context.Context,
+	be *backend.Backend,
+	clientInfo *database.ClientInfo,
+	actorID *time.ActorID,
+	items []BatchItem,
+	parallelism int,
+) []error {
+	if parallelism <= 0 {
+		parallelism = DefaultBatchParallelism
+	}
+
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for idx, item := range items {
+		idx, item := idx, item
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[idx] = PushPullDetach(ctx, be, clientInfo, actorID, item)
+		}()
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// PushPullDetach locks, builds a presence-clear pack for, and pushes-pulls a
+// single document detach. It is the shared core of both the single-document
+// DetachDocument RPC and PushPullBatch, so the lock-then-pushpull sequence
+// for a presence-clear detach lives in exactly one place.
+func PushPullDetach(
+	ctx context.Context,
+	be *backend.Backend,
+	clientInfo *database.ClientInfo,
+	actorID *time.ActorID,
+	item BatchItem,
+) error {
+	locker, err := be.Coordinator.NewLocker(ctx, item.lockKey())
+	if err != nil {
+		return err
+	}
+	if err := locker.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if err := locker.Unlock(ctx); err != nil {
+			logging.DefaultLogger().Error(err)
+		}
+	}()
+
+	pack := NewPresenceClearPack(item.DocInfo, clientInfo.Checkpoint(item.DocInfo.ID), actorID)
+
+	_, err = PushPull(ctx, be, item.Project, clientInfo, item.DocInfo, pack, PushPullOptions{
+		Mode:   types.SyncModePushPull,
+		Status: document.StatusDetached,
+	})
+	return err
+}
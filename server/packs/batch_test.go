@@ -0,0 +1,113 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package packs_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	gotime "time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yorkie-team/yorkie/api/types"
+	"github.com/yorkie-team/yorkie/pkg/document/key"
+	"github.com/yorkie-team/yorkie/pkg/document/time"
+	"github.com/yorkie-team/yorkie/server/backend"
+	"github.com/yorkie-team/yorkie/server/backend/database"
+	"github.com/yorkie-team/yorkie/server/backend/sync"
+	"github.com/yorkie-team/yorkie/server/packs"
+)
+
+// countingLocker is a sync.Locker fake that records how many Lock calls are
+// in flight at once and always fails, so PushPullDetach returns right after
+// locking without ever reaching the real PushPull/DB path. Since a failed
+// Lock is never followed by Unlock, each Lock call counts itself out again
+// before returning, after holding briefly so that concurrent calls overlap.
+type countingLocker struct {
+	lockKey     string
+	inFlight    *int32
+	maxInFlight *int32
+}
+
+func (l *countingLocker) Lock(_ context.Context) error {
+	n := atomic.AddInt32(l.inFlight, 1)
+	defer atomic.AddInt32(l.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(l.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(l.maxInFlight, max, n) {
+			break
+		}
+	}
+	gotime.Sleep(10 * gotime.Millisecond)
+	return fmt.Errorf("locked: %s", l.lockKey)
+}
+
+func (l *countingLocker) Unlock(_ context.Context) error {
+	return nil
+}
+
+// countingCoordinator is a sync.Coordinator fake that hands out
+// countingLockers, so PushPullBatch's real lock-acquisition and fan-out can
+// be exercised without a live backend.
+type countingCoordinator struct {
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (c *countingCoordinator) NewLocker(_ context.Context, key string) (sync.Locker, error) {
+	return &countingLocker{lockKey: key, inFlight: &c.inFlight, maxInFlight: &c.maxInFlight}, nil
+}
+
+func (c *countingCoordinator) Close() error {
+	return nil
+}
+
+func newBatchItem(projectID, docKey string) packs.BatchItem {
+	return packs.BatchItem{
+		Project: &types.Project{ID: types.ID(projectID)},
+		DocInfo: &database.DocInfo{ID: types.ID(docKey), Key: key.Key(docKey)},
+	}
+}
+
+// TestPushPullBatch asserts that PushPullBatch drives every item through the
+// real locking path with its worker pool bounded at parallelism, and returns
+// one error per item aligned by index.
+func TestPushPullBatch(t *testing.T) {
+	const parallelism = 4
+
+	items := make([]packs.BatchItem, 16)
+	for i := range items {
+		items[i] = newBatchItem("project-1", fmt.Sprintf("doc-%02d", i))
+	}
+
+	coordinator := &countingCoordinator{}
+	be := &backend.Backend{Coordinator: coordinator}
+	clientInfo := &database.ClientInfo{ID: types.ID("client-1")}
+	actorID, err := time.ActorIDFromHex("000000000000000000000000")
+	assert.NoError(t, err)
+
+	errs := packs.PushPullBatch(context.Background(), be, clientInfo, actorID, items, parallelism)
+
+	assert.Len(t, errs, len(items))
+	for i, err := range errs {
+		assert.ErrorContains(t, err, packs.PushPullKey(items[i].Project.ID, items[i].DocInfo.Key))
+	}
+	assert.LessOrEqual(t, int(coordinator.maxInFlight), parallelism)
+	assert.Greater(t, int(coordinator.maxInFlight), 1)
+}
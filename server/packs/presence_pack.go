@@ -0,0 +1,54 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package packs
+
+import (
+	"github.com/yorkie-team/yorkie/pkg/document/change"
+	"github.com/yorkie-team/yorkie/pkg/document/checkpoint"
+	innerpresence "github.com/yorkie-team/yorkie/pkg/document/presence"
+	"github.com/yorkie-team/yorkie/pkg/document/time"
+	"github.com/yorkie-team/yorkie/server/backend/database"
+)
+
+// NewPresenceClearPack creates a ChangePack that only clears the presence of
+// the given actor. Unlike BuildDocForCheckpoint, it does not read the
+// document's CRDT root, so it stays cheap even for large documents. It is
+// meant for detaches that don't originate from a live client session, such as
+// the ones triggered by cluster-to-cluster forwarding, where the only thing
+// that needs to propagate to other watchers is that the actor's presence is
+// gone.
+func NewPresenceClearPack(
+	docInfo *database.DocInfo,
+	cp checkpoint.Checkpoint,
+	actorID *time.ActorID,
+) *change.Pack {
+	cn := change.New(
+		change.InitialID.SyncClocks(cp.ServerSeq, cp.ClientSeq).SetActor(actorID),
+		"",
+		nil,
+		&innerpresence.PresenceChange{
+			ChangeType: innerpresence.Clear,
+		},
+	)
+
+	return change.NewPack(
+		docInfo.Key,
+		cp.Forward(checkpoint.New(cp.ServerSeq, cp.ClientSeq+1)),
+		[]*change.Change{cn},
+		nil,
+	)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package packs_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yorkie-team/yorkie/pkg/document"
+	"github.com/yorkie-team/yorkie/pkg/document/checkpoint"
+	"github.com/yorkie-team/yorkie/pkg/document/json"
+	"github.com/yorkie-team/yorkie/pkg/document/key"
+	"github.com/yorkie-team/yorkie/pkg/document/presence"
+	"github.com/yorkie-team/yorkie/pkg/document/time"
+	"github.com/yorkie-team/yorkie/server/backend/database"
+	"github.com/yorkie-team/yorkie/server/packs"
+)
+
+// TestNewPresenceClearPackEquivalence asserts that the minimal pack built by
+// NewPresenceClearPack puts a client in the same state as the full
+// client-side path it replaces: starting from the same checkpoint, both
+// must produce a single presence-clear change carrying the same actor and
+// the same advanced checkpoint vector.
+func TestNewPresenceClearPackEquivalence(t *testing.T) {
+	docKey := key.Key("test-doc")
+	docInfo := &database.DocInfo{Key: docKey}
+
+	actorID, err := time.ActorIDFromHex(time.InitialActorID.String())
+	assert.NoError(t, err)
+
+	cp := checkpoint.New(0, 0)
+
+	clusterPack := packs.NewPresenceClearPack(docInfo, cp, actorID)
+	assert.Len(t, clusterPack.Changes, 1)
+	assert.Equal(t, docKey, clusterPack.DocumentKey)
+
+	clientDoc := document.New(docKey)
+	assert.NoError(t, clientDoc.Update(func(root *json.Object, p *presence.Presence) error {
+		p.Clear()
+		return nil
+	}))
+	clientPack := clientDoc.CreateChangePack()
+	assert.Len(t, clientPack.Changes, 1)
+
+	clusterChange := clusterPack.Changes[0]
+	clientChange := clientPack.Changes[0]
+
+	// Same presence effect.
+	assert.Equal(t, clientChange.PresenceChange(), clusterChange.PresenceChange())
+
+	// Same actor and checkpoint vector: both started from (0, 0) and
+	// applied one local change, so the resulting client sequence and
+	// actor must line up exactly.
+	assert.Equal(t, actorID, clusterChange.ID().ActorID())
+	assert.Equal(t, clientChange.ID().ActorID(), clusterChange.ID().ActorID())
+	assert.Equal(t, clientChange.ID().ClientSeq(), clusterChange.ID().ClientSeq())
+	assert.Equal(t, clientPack.Checkpoint, clusterPack.Checkpoint)
+}
+
+// newLargeDoc builds a document with n list elements, simulating the
+// "large document" case the old BuildDocForCheckpoint path had to read in
+// full before it could produce a presence-clear pack.
+func newLargeDoc(t testing.TB, n int) *document.Document {
+	doc := document.New(key.Key("large-doc"))
+	assert.NoError(t, doc.Update(func(root *json.Object, p *presence.Presence) error {
+		list := root.SetNewArray("values")
+		for i := 0; i < n; i++ {
+			list.AddString(strconv.Itoa(i))
+		}
+		return nil
+	}))
+	return doc
+}
+
+// BenchmarkBuildDocForCheckpointEquivalent times the old path this change
+// replaces: loading the full document and clearing presence on it, the cost
+// of which scales with document size.
+func BenchmarkBuildDocForCheckpointEquivalent(b *testing.B) {
+	doc := newLargeDoc(b, 10000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		assert.NoError(b, doc.Update(func(root *json.Object, p *presence.Presence) error {
+			p.Clear()
+			return nil
+		}))
+		doc.CreateChangePack()
+	}
+}
+
+// BenchmarkNewPresenceClearPack times the new path, which never touches the
+// document's CRDT root and so should stay flat regardless of document size.
+func BenchmarkNewPresenceClearPack(b *testing.B) {
+	docInfo := &database.DocInfo{Key: key.Key("bench-doc")}
+	actorID, err := time.ActorIDFromHex(time.InitialActorID.String())
+	assert.NoError(b, err)
+	cp := checkpoint.New(1, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		packs.NewPresenceClearPack(docInfo, cp, actorID)
+	}
+}
@@ -18,36 +18,63 @@ package rpc
 
 import (
 	"context"
+	"net/http"
 
 	"connectrpc.com/connect"
 
 	"github.com/yorkie-team/yorkie/api/converter"
 	"github.com/yorkie-team/yorkie/api/types"
 	api "github.com/yorkie-team/yorkie/api/yorkie/v1"
+	"github.com/yorkie-team/yorkie/api/yorkie/v1/v1connect"
 	"github.com/yorkie-team/yorkie/pkg/document"
-	"github.com/yorkie-team/yorkie/pkg/document/json"
-	"github.com/yorkie-team/yorkie/pkg/document/presence"
 	"github.com/yorkie-team/yorkie/pkg/document/time"
 	"github.com/yorkie-team/yorkie/server/backend"
+	"github.com/yorkie-team/yorkie/server/backend/sync"
 	"github.com/yorkie-team/yorkie/server/clients"
+	"github.com/yorkie-team/yorkie/server/cluster"
 	"github.com/yorkie-team/yorkie/server/documents"
 	"github.com/yorkie-team/yorkie/server/logging"
 	"github.com/yorkie-team/yorkie/server/packs"
 )
 
+// RegisterClusterServiceHandler constructs a clusterServer and mounts it on
+// mux. clusterServer itself is unexported, so this is the one call site
+// that should ever construct it; wherever this node's HTTP server is
+// assembled should call this alongside the handlers for the other RPC
+// services, passing the same backend and the node's cluster.Router.
+func RegisterClusterServiceHandler(mux *http.ServeMux, backend *backend.Backend, router *cluster.Router) {
+	path, handler := v1connect.NewClusterServiceHandler(newClusterServer(backend, router))
+	mux.Handle(path, handler)
+}
+
 // clusterServer is a server that provides the internal Yorkie cluster service.
 // This service is used for communication between nodes in the Yorkie cluster.
 type clusterServer struct {
 	backend *backend.Backend
+	router  *cluster.Router
 }
 
-// newClusterServer creates a new instance of clusterServer.
-func newClusterServer(backend *backend.Backend) *clusterServer {
+// newClusterServer creates a new instance of clusterServer. router resolves
+// whether a given document is owned by this node or a peer, so that RPCs
+// arriving here for a document this node doesn't currently own can be
+// forwarded instead of silently processed as if it did.
+func newClusterServer(backend *backend.Backend, router *cluster.Router) *clusterServer {
 	return &clusterServer{
 		backend: backend,
+		router:  router,
 	}
 }
 
+// routeLocally reports whether docRefKey is owned by this node. If not, it
+// returns the Route so the caller can forward the request to the owner.
+func (s *clusterServer) routeLocally(ctx context.Context, docRefKey types.DocRefKey) (bool, cluster.Route, error) {
+	route, err := s.router.Route(ctx, docRefKey)
+	if err != nil {
+		return false, cluster.Route{}, err
+	}
+	return route.Local, route, nil
+}
+
 // DetachDocument detaches the given document from the given client.
 func (s *clusterServer) DetachDocument(
 	ctx context.Context,
@@ -61,19 +88,25 @@ func (s *clusterServer) DetachDocument(
 	summary := converter.FromDocumentSummary(req.Msg.DocumentSummary)
 	project := converter.FromProject(req.Msg.Project)
 
-	locker, err := s.backend.Coordinator.NewLocker(ctx, packs.PushPullKey(project.ID, summary.Key))
-	if err != nil {
-		return nil, err
+	docRefKey := types.DocRefKey{
+		ProjectID: project.ID,
+		DocID:     summary.ID,
 	}
 
-	if err := locker.Lock(ctx); err != nil {
+	local, route, err := s.routeLocally(ctx, docRefKey)
+	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := locker.Unlock(ctx); err != nil {
-			logging.DefaultLogger().Error(err)
+	if !local {
+		docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		if err := route.Client.DetachDocument(ctx, project, docInfo, types.IDFromActorID(actorID)); err != nil {
+			return nil, err
+		}
+		return connect.NewResponse(&api.ClusterServiceDetachDocumentResponse{}), nil
+	}
 
 	clientInfo, err := clients.FindActiveClientInfo(ctx, s.backend, types.ClientRefKey{
 		ProjectID: project.ID,
@@ -83,46 +116,322 @@ func (s *clusterServer) DetachDocument(
 		return nil, err
 	}
 
+	docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Locks, clears the actor's presence, and pushes-pulls the result,
+	// the same shared path DetachDocuments uses for batched detaches.
+	if err := packs.PushPullDetach(ctx, s.backend, clientInfo, actorID, packs.BatchItem{
+		Project: project,
+		DocInfo: docInfo,
+	}); err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&api.ClusterServiceDetachDocumentResponse{}), nil
+}
+
+// DetachDocuments detaches every given document from the given client in a
+// single call. It is used by client deactivation, which otherwise would
+// need one ClusterService RPC per attached document. Documents not owned by
+// this node are grouped by owner and forwarded with one DetachDocuments call
+// per owning node, the same per-item routing DetachDocument does.
+func (s *clusterServer) DetachDocuments(
+	ctx context.Context,
+	req *connect.Request[api.ClusterServiceDetachDocumentsRequest],
+) (*connect.Response[api.ClusterServiceDetachDocumentsResponse], error) {
+	if len(req.Msg.Documents) == 0 {
+		// A client that deactivates without ever attaching a document is a
+		// normal, valid case: there is nothing to detach.
+		return connect.NewResponse(&api.ClusterServiceDetachDocumentsResponse{}), nil
+	}
+
+	actorID, err := time.ActorIDFromHex(req.Msg.ClientId)
+	if err != nil {
+		return nil, err
+	}
+	clientID := types.IDFromActorID(actorID)
+
+	localItemsByProject := make(map[types.ID][]packs.BatchItem)
+	remoteItems := make(map[types.ID][]cluster.DetachDocumentsItem)
+	remoteClients := make(map[types.ID]*cluster.Client)
+
+	for _, elem := range req.Msg.Documents {
+		summary := converter.FromDocumentSummary(elem.DocumentSummary)
+		project := converter.FromProject(elem.Project)
+
+		docRefKey := types.DocRefKey{
+			ProjectID: project.ID,
+			DocID:     summary.ID,
+		}
+
+		local, route, err := s.routeLocally(ctx, docRefKey)
+		if err != nil {
+			return nil, err
+		}
+
+		docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if !local {
+			remoteItems[route.NodeID] = append(remoteItems[route.NodeID], cluster.DetachDocumentsItem{
+				Project: project,
+				DocInfo: docInfo,
+			})
+			remoteClients[route.NodeID] = route.Client
+			continue
+		}
+
+		localItemsByProject[project.ID] = append(localItemsByProject[project.ID], packs.BatchItem{
+			Project: project,
+			DocInfo: docInfo,
+		})
+	}
+
+	for nodeID, items := range remoteItems {
+		if err := remoteClients[nodeID].DetachDocuments(ctx, items, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	for projectID, items := range localItemsByProject {
+		clientInfo, err := clients.FindActiveClientInfo(ctx, s.backend, types.ClientRefKey{
+			ProjectID: projectID,
+			ClientID:  clientID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, err := range packs.PushPullBatch(ctx, s.backend, clientInfo, actorID, items, packs.DefaultBatchParallelism) {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return connect.NewResponse(&api.ClusterServiceDetachDocumentsResponse{}), nil
+}
+
+// AttachDocument force-attaches the given document to the given client on
+// the node that owns the document.
+func (s *clusterServer) AttachDocument(
+	ctx context.Context,
+	req *connect.Request[api.ClusterServiceAttachDocumentRequest],
+) (*connect.Response[api.ClusterServiceAttachDocumentResponse], error) {
+	actorID, err := time.ActorIDFromHex(req.Msg.ClientId)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := converter.FromDocumentSummary(req.Msg.DocumentSummary)
+	project := converter.FromProject(req.Msg.Project)
+
 	docRefKey := types.DocRefKey{
 		ProjectID: project.ID,
 		DocID:     summary.ID,
 	}
 
-	docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+	pack, err := converter.FromChangePack(req.Msg.ChangePack)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO(hackerwins): BuildDocForCheckpoint is expensive because it reads the entire document.
-	// We need to optimize this by creating a ChangePack directly.
-	// 01. Create ChangePack with clear presence.
-	doc, err := packs.BuildDocForCheckpoint(ctx, s.backend, docInfo, clientInfo.Checkpoint(summary.ID), actorID)
+	local, route, err := s.routeLocally(ctx, docRefKey)
 	if err != nil {
 		return nil, err
 	}
+	if !local {
+		docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := route.Client.AttachDocument(ctx, project, docInfo, types.IDFromActorID(actorID), pack); err != nil {
+			return nil, err
+		}
+		return connect.NewResponse(&api.ClusterServiceAttachDocumentResponse{}), nil
+	}
 
-	if err := doc.Update(func(root *json.Object, p *presence.Presence) error {
-		p.Clear()
-		return nil
-	}); err != nil {
+	locker, err := s.backend.Coordinator.NewLocker(ctx, packs.PushPullKey(project.ID, summary.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := locker.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := locker.Unlock(ctx); err != nil {
+			logging.DefaultLogger().Error(err)
+		}
+	}()
+
+	clientInfo, err := clients.FindActiveClientInfo(ctx, s.backend, types.ClientRefKey{
+		ProjectID: project.ID,
+		ClientID:  types.IDFromActorID(actorID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+	if err != nil {
 		return nil, err
 	}
 
-	// 02. PushPull with the created ChangePack.
 	if _, err := packs.PushPull(
 		ctx,
 		s.backend,
 		project,
 		clientInfo,
 		docInfo,
-		doc.CreateChangePack(),
+		pack,
 		packs.PushPullOptions{
 			Mode:   types.SyncModePushPull,
-			Status: document.StatusDetached,
+			Status: document.StatusAttached,
 		},
 	); err != nil {
 		return nil, err
 	}
 
-	return connect.NewResponse(&api.ClusterServiceDetachDocumentResponse{}), nil
+	return connect.NewResponse(&api.ClusterServiceAttachDocumentResponse{}), nil
+}
+
+// RemoveDocument tombstones the given document cluster-wide.
+func (s *clusterServer) RemoveDocument(
+	ctx context.Context,
+	req *connect.Request[api.ClusterServiceRemoveDocumentRequest],
+) (*connect.Response[api.ClusterServiceRemoveDocumentResponse], error) {
+	summary := converter.FromDocumentSummary(req.Msg.DocumentSummary)
+	project := converter.FromProject(req.Msg.Project)
+
+	docRefKey := types.DocRefKey{
+		ProjectID: project.ID,
+		DocID:     summary.ID,
+	}
+
+	local, route, err := s.routeLocally(ctx, docRefKey)
+	if err != nil {
+		return nil, err
+	}
+	if !local {
+		docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := route.Client.RemoveDocument(ctx, project, docInfo); err != nil {
+			return nil, err
+		}
+		return connect.NewResponse(&api.ClusterServiceRemoveDocumentResponse{}), nil
+	}
+
+	locker, err := s.backend.Coordinator.NewLocker(ctx, packs.PushPullKey(project.ID, summary.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := locker.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := locker.Unlock(ctx); err != nil {
+			logging.DefaultLogger().Error(err)
+		}
+	}()
+
+	docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := documents.RemoveDocument(ctx, s.backend, docInfo); err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&api.ClusterServiceRemoveDocumentResponse{}), nil
+}
+
+// CompactDocument triggers history compaction for the given document on the
+// node that owns it.
+func (s *clusterServer) CompactDocument(
+	ctx context.Context,
+	req *connect.Request[api.ClusterServiceCompactDocumentRequest],
+) (*connect.Response[api.ClusterServiceCompactDocumentResponse], error) {
+	summary := converter.FromDocumentSummary(req.Msg.DocumentSummary)
+	project := converter.FromProject(req.Msg.Project)
+
+	docRefKey := types.DocRefKey{
+		ProjectID: project.ID,
+		DocID:     summary.ID,
+	}
+
+	local, route, err := s.routeLocally(ctx, docRefKey)
+	if err != nil {
+		return nil, err
+	}
+	if !local {
+		docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := route.Client.CompactDocument(ctx, project, docInfo); err != nil {
+			return nil, err
+		}
+		return connect.NewResponse(&api.ClusterServiceCompactDocumentResponse{}), nil
+	}
+
+	locker, err := s.backend.Coordinator.NewLocker(ctx, packs.PushPullKey(project.ID, summary.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := locker.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := locker.Unlock(ctx); err != nil {
+			logging.DefaultLogger().Error(err)
+		}
+	}()
+
+	docInfo, err := documents.FindDocInfoByRefKey(ctx, s.backend, docRefKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := documents.CompactDocument(ctx, s.backend, docInfo); err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&api.ClusterServiceCompactDocumentResponse{}), nil
+}
+
+// BroadcastEvent publishes the given DocEvent to the local pubsub of this
+// node, so that watchers connected here are notified of an event that
+// originated on a peer node.
+func (s *clusterServer) BroadcastEvent(
+	ctx context.Context,
+	req *connect.Request[api.ClusterServiceBroadcastEventRequest],
+) (*connect.Response[api.ClusterServiceBroadcastEventResponse], error) {
+	summary := converter.FromDocumentSummary(req.Msg.DocumentSummary)
+	project := converter.FromProject(req.Msg.Project)
+
+	docRefKey := types.DocRefKey{
+		ProjectID: project.ID,
+		DocID:     summary.ID,
+	}
+
+	s.backend.PubSub.Publish(ctx, types.ID(req.Msg.PublisherId), docRefKey, sync.DocEvent{
+		Type:      req.Msg.EventType,
+		Publisher: types.ID(req.Msg.PublisherId),
+		Body: sync.DocEventBody{
+			Payload: req.Msg.Payload,
+		},
+	})
+
+	return connect.NewResponse(&api.ClusterServiceBroadcastEventResponse{}), nil
 }
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2024 The Yorkie Authors. All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	gotime "time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yorkie-team/yorkie/api/types"
+	"github.com/yorkie-team/yorkie/server/cluster"
+)
+
+// fakeRegistry is a minimal cluster.Registry fake: just enough of a fixed
+// member list to drive Router.Route, without a live coordinator.
+type fakeRegistry struct {
+	members []cluster.NodeInfo
+}
+
+func (r *fakeRegistry) Register(_ context.Context, _ cluster.NodeInfo) error { return nil }
+func (r *fakeRegistry) Heartbeat(_ context.Context, _ types.ID) error        { return nil }
+func (r *fakeRegistry) Deregister(_ context.Context, _ types.ID) error       { return nil }
+func (r *fakeRegistry) Members(_ context.Context, _ gotime.Duration) ([]cluster.NodeInfo, error) {
+	return r.members, nil
+}
+
+// TestClusterServerRouteLocallyMatchesRouter asserts that routeLocally --
+// the per-item check that AttachDocument, RemoveDocument, CompactDocument
+// and DetachDocuments all run before touching local state -- agrees with
+// the underlying Router: a document owned by this node comes back local,
+// and one owned by a peer comes back with a Route to forward through
+// instead of being silently processed as if this node owned it.
+//
+// A full end-to-end test of each handler (matching the AttachDocument
+// ChangePack-drop regression this comment was written in response to) also
+// needs fakes for backend.Backend's DB, server/documents and server/clients,
+// none of which are available in this checkout; this covers the routing
+// contract every one of those handlers is built on.
+func TestClusterServerRouteLocallyMatchesRouter(t *testing.T) {
+	registry := &fakeRegistry{members: []cluster.NodeInfo{
+		{ID: "node-a", Addr: "a:1101"},
+		{ID: "node-b", Addr: "b:1101"},
+	}}
+
+	s := &clusterServer{router: cluster.NewRouter("node-a", registry)}
+
+	var sawLocal, sawRemote bool
+	for i := 0; i < 32 && !(sawLocal && sawRemote); i++ {
+		docRefKey := types.DocRefKey{
+			ProjectID: types.ID("project-1"),
+			DocID:     types.ID(fmt.Sprintf("doc-%02d", i)),
+		}
+
+		local, route, err := s.routeLocally(context.Background(), docRefKey)
+		assert.NoError(t, err)
+
+		if local {
+			sawLocal = true
+			continue
+		}
+
+		sawRemote = true
+		assert.Equal(t, types.ID("node-b"), route.NodeID)
+		assert.NotNil(t, route.Client)
+	}
+
+	assert.True(t, sawLocal, "expected at least one document to route locally")
+	assert.True(t, sawRemote, "expected at least one document to route to the peer")
+}